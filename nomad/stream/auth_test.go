@@ -0,0 +1,134 @@
+package stream
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHMACSigner_SignAndVerifyRoundTrip(t *testing.T) {
+	signer, err := NewHMACSigner(HMACConfig{Secret: "s3cr3t"})
+	require.NoError(t, err)
+
+	body := []byte(`{"hello":"world"}`)
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, signer.Sign(req, body))
+
+	sig := req.Header.Get(DefaultSignatureHeader)
+	ts := req.Header.Get(DefaultTimestampHeader)
+	require.NotEmpty(t, sig)
+	require.NotEmpty(t, ts)
+
+	require.True(t, VerifyHMAC(HMACSHA256, "s3cr3t", ts, sig, body, 0))
+}
+
+func TestHMACSigner_SHA512(t *testing.T) {
+	signer, err := NewHMACSigner(HMACConfig{Secret: "s3cr3t", Algorithm: HMACSHA512})
+	require.NoError(t, err)
+
+	body := []byte("payload")
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", nil)
+	require.NoError(t, err)
+	require.NoError(t, signer.Sign(req, body))
+
+	sig := req.Header.Get(DefaultSignatureHeader)
+	ts := req.Header.Get(DefaultTimestampHeader)
+
+	require.True(t, VerifyHMAC(HMACSHA512, "s3cr3t", ts, sig, body, 0))
+	require.False(t, VerifyHMAC(HMACSHA256, "s3cr3t", ts, sig, body, 0))
+}
+
+func TestVerifyHMAC_WrongSecretOrBodyFails(t *testing.T) {
+	signer, err := NewHMACSigner(HMACConfig{Secret: "s3cr3t"})
+	require.NoError(t, err)
+
+	body := []byte("payload")
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", nil)
+	require.NoError(t, err)
+	require.NoError(t, signer.Sign(req, body))
+
+	sig := req.Header.Get(DefaultSignatureHeader)
+	ts := req.Header.Get(DefaultTimestampHeader)
+
+	require.False(t, VerifyHMAC(HMACSHA256, "wrong-secret", ts, sig, body, 0))
+	require.False(t, VerifyHMAC(HMACSHA256, "s3cr3t", ts, sig, []byte("tampered"), 0))
+}
+
+func TestVerifyHMAC_RejectsStaleTimestamp(t *testing.T) {
+	secret := "s3cr3t"
+	body := []byte("payload")
+
+	staleTS := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+	sig := signHMACForTest(t, secret, staleTS, body)
+
+	require.False(t, VerifyHMAC(HMACSHA256, secret, staleTS, sig, body, 5*time.Minute))
+	require.True(t, VerifyHMAC(HMACSHA256, secret, staleTS, sig, body, 15*time.Minute))
+}
+
+func TestVerifyHMAC_RejectsFutureTimestamp(t *testing.T) {
+	secret := "s3cr3t"
+	body := []byte("payload")
+
+	futureTS := strconv.FormatInt(time.Now().Add(10*time.Minute).Unix(), 10)
+	sig := signHMACForTest(t, secret, futureTS, body)
+
+	require.False(t, VerifyHMAC(HMACSHA256, secret, futureTS, sig, body, 5*time.Minute))
+}
+
+func TestVerifyHMAC_RejectsMalformedTimestamp(t *testing.T) {
+	secret := "s3cr3t"
+	body := []byte("payload")
+	sig := signHMACForTest(t, secret, "not-a-number", body)
+
+	require.False(t, VerifyHMAC(HMACSHA256, secret, "not-a-number", sig, body, 0))
+}
+
+// signHMACForTest computes the same "<timestamp>.<body>" HMAC as
+// HMACSigner.Sign, but over a caller-supplied timestamp instead of
+// time.Now(), so replay-window tests can control the signed age directly.
+func signHMACForTest(t *testing.T, secret, ts string, body []byte) string {
+	t.Helper()
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, err := mac.Write(append([]byte(ts+"."), body...))
+	require.NoError(t, err)
+
+	return fmt.Sprintf("%x", mac.Sum(nil))
+}
+
+func TestBearerTokenSigner(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", nil)
+	require.NoError(t, err)
+
+	signer := &BearerTokenSigner{Token: "tok"}
+	require.NoError(t, signer.Sign(req, nil))
+	require.Equal(t, "Bearer tok", req.Header.Get("Authorization"))
+
+	require.Error(t, (&BearerTokenSigner{}).Sign(req, nil))
+}
+
+func TestStaticHeaderSigner(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", nil)
+	require.NoError(t, err)
+
+	signer := &StaticHeaderSigner{Headers: map[string]string{"X-Api-Key": "abc"}}
+	require.NoError(t, signer.Sign(req, nil))
+	require.Equal(t, "abc", req.Header.Get("X-Api-Key"))
+}
+
+func TestNewHMACSigner_Validation(t *testing.T) {
+	_, err := NewHMACSigner(HMACConfig{})
+	require.Error(t, err)
+
+	_, err = NewHMACSigner(HMACConfig{Secret: "s", Algorithm: "sha1"})
+	require.True(t, strings.Contains(err.Error(), "unsupported"))
+}
@@ -0,0 +1,20 @@
+package stream
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// backoffDuration computes the exponential backoff with jitter for the given
+// attempt (0-indexed): min(cap, base*2^attempt) * (0.5 + rand*0.5).
+func backoffDuration(base, cap time.Duration, attempt int) time.Duration {
+	mult := math.Pow(2, float64(attempt))
+	d := time.Duration(float64(base) * mult)
+	if d <= 0 || d > cap {
+		d = cap
+	}
+
+	jitter := 0.5 + rand.Float64()*0.5
+	return time.Duration(float64(d) * jitter)
+}
@@ -0,0 +1,44 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoffDuration_WithinJitterBounds(t *testing.T) {
+	base := 100 * time.Millisecond
+	cap := 10 * time.Second
+
+	for attempt := 0; attempt < 10; attempt++ {
+		want := base << uint(attempt)
+		if want <= 0 || want > cap {
+			want = cap
+		}
+
+		for i := 0; i < 50; i++ {
+			d := backoffDuration(base, cap, attempt)
+			require.GreaterOrEqual(t, d, time.Duration(float64(want)*0.5))
+			require.LessOrEqual(t, d, want)
+		}
+	}
+}
+
+func TestBackoffDuration_CapsAtMaxBackoff(t *testing.T) {
+	base := 500 * time.Millisecond
+	cap := 2 * time.Second
+
+	d := backoffDuration(base, cap, 20)
+	require.LessOrEqual(t, d, cap)
+}
+
+func TestBackoffDuration_NeverNegativeOrZero(t *testing.T) {
+	base := 500 * time.Millisecond
+	cap := 30 * time.Second
+
+	for attempt := 0; attempt < 5; attempt++ {
+		d := backoffDuration(base, cap, attempt)
+		require.Greater(t, d, time.Duration(0))
+	}
+}
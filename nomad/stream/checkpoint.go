@@ -0,0 +1,91 @@
+package stream
+
+import (
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// checkpointBucket is the top-level BoltDB bucket that holds one key per
+// sink, mapping the sink's name to the last successfully-acked events.Index.
+var checkpointBucket = []byte("sink-checkpoints")
+
+// CheckpointStore persists the last successfully delivered index for each
+// sink so that a restarted sink can resume from where it left off instead
+// of replaying (or dropping) events.
+type CheckpointStore struct {
+	db *bbolt.DB
+}
+
+// NewCheckpointStore opens (creating if necessary) a BoltDB file at path and
+// ensures the checkpoint bucket exists.
+func NewCheckpointStore(path string) (*CheckpointStore, error) {
+	db, err := bbolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("opening checkpoint store: %w", err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(checkpointBucket)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("initializing checkpoint bucket: %w", err)
+	}
+
+	return &CheckpointStore{db: db}, nil
+}
+
+// Load returns the last acked index recorded for sink, or 0 if none has
+// been recorded yet.
+func (c *CheckpointStore) Load(sink string) (uint64, error) {
+	var index uint64
+	err := c.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(checkpointBucket)
+		v := b.Get([]byte(sink))
+		if v == nil {
+			return nil
+		}
+		index = bytesToUint64(v)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("loading checkpoint for sink %q: %w", sink, err)
+	}
+
+	return index, nil
+}
+
+// Save durably records index as the last successfully acked index for sink.
+func (c *CheckpointStore) Save(sink string, index uint64) error {
+	err := c.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(checkpointBucket)
+		return b.Put([]byte(sink), uint64ToBytes(index))
+	})
+	if err != nil {
+		return fmt.Errorf("saving checkpoint for sink %q: %w", sink, err)
+	}
+
+	return nil
+}
+
+// Close releases the underlying BoltDB handle.
+func (c *CheckpointStore) Close() error {
+	return c.db.Close()
+}
+
+func uint64ToBytes(v uint64) []byte {
+	b := make([]byte, 8)
+	for i := 0; i < 8; i++ {
+		b[i] = byte(v >> (56 - 8*i))
+	}
+	return b
+}
+
+func bytesToUint64(b []byte) uint64 {
+	var v uint64
+	for i := 0; i < 8 && i < len(b); i++ {
+		v = v<<8 | uint64(b[i])
+	}
+	return v
+}
@@ -0,0 +1,76 @@
+package stream
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointStore_LoadDefaultsToZero(t *testing.T) {
+	store := newTestCheckpointStore(t)
+
+	index, err := store.Load("sink-a")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), index)
+}
+
+func TestCheckpointStore_SaveAndLoad(t *testing.T) {
+	store := newTestCheckpointStore(t)
+
+	require.NoError(t, store.Save("sink-a", 42))
+	require.NoError(t, store.Save("sink-b", 7))
+
+	index, err := store.Load("sink-a")
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), index)
+
+	index, err = store.Load("sink-b")
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), index)
+}
+
+func TestCheckpointStore_SaveOverwrites(t *testing.T) {
+	store := newTestCheckpointStore(t)
+
+	require.NoError(t, store.Save("sink-a", 1))
+	require.NoError(t, store.Save("sink-a", 2))
+
+	index, err := store.Load("sink-a")
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), index)
+}
+
+func TestCheckpointStore_SurvivesReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "checkpoints.db")
+
+	store, err := NewCheckpointStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Save("sink-a", 99))
+	require.NoError(t, store.Close())
+
+	reopened, err := NewCheckpointStore(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { reopened.Close() })
+
+	index, err := reopened.Load("sink-a")
+	require.NoError(t, err)
+	require.Equal(t, uint64(99), index)
+}
+
+func TestUint64BytesRoundTrip(t *testing.T) {
+	for _, v := range []uint64{0, 1, 42, 1 << 40, ^uint64(0)} {
+		require.Equal(t, v, bytesToUint64(uint64ToBytes(v)))
+	}
+}
+
+func newTestCheckpointStore(t *testing.T) *CheckpointStore {
+	t.Helper()
+
+	store, err := NewCheckpointStore(filepath.Join(t.TempDir(), "checkpoints.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	return store
+}
@@ -0,0 +1,22 @@
+package stream
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+)
+
+// gzipCompress gzips body at the default compression level.
+func gzipCompress(body []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	w := gzip.NewWriter(buf)
+
+	if _, err := w.Write(body); err != nil {
+		return nil, fmt.Errorf("writing gzip stream: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("closing gzip stream: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
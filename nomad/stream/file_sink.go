@@ -0,0 +1,136 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// FileCfg configures a FileSink.
+type FileCfg struct {
+	// Name uniquely identifies this sink and is used as the checkpoint
+	// store key.
+	Name string
+
+	// Path is the JSONL file events are appended to.
+	Path string
+
+	// MaxBytes rotates the file (renaming it to Path+".1") before a write
+	// that would take it past this size. Zero disables rotation.
+	MaxBytes int64
+}
+
+// FileSink is a Sink that appends each event as a line of JSON to a local
+// file, primarily intended for local debugging.
+type FileSink struct {
+	config FileCfg
+
+	mu       sync.Mutex
+	f        *os.File
+	curBytes int64
+}
+
+// NewFileSink constructs a FileSink and wraps it in a SinkRunner subscribed
+// to broker per subReq.
+func NewFileSink(cfg *FileCfg, runnerCfg *RunnerCfg, broker *EventBroker, subReq *SubscribeRequest) (*SinkRunner, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("file sink requires a path")
+	}
+
+	f, size, err := openAppend(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("opening sink file %q: %w", cfg.Path, err)
+	}
+
+	fs := &FileSink{
+		config:   *cfg,
+		f:        f,
+		curBytes: size,
+	}
+
+	return NewSinkRunner(fs, runnerCfg, broker, subReq)
+}
+
+func openAppend(path string) (*os.File, int64, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+
+	return f, info.Size(), nil
+}
+
+func (fs *FileSink) Name() string {
+	return fs.config.Name
+}
+
+func (fs *FileSink) Type() string {
+	return "file"
+}
+
+func (fs *FileSink) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.f.Close()
+}
+
+// Send appends one JSON line per event, rotating the file first if it would
+// exceed MaxBytes.
+func (fs *FileSink) Send(_ context.Context, e *structs.Events) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for _, event := range e.Events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("marshaling event: %w", err)
+		}
+		line = append(line, '\n')
+
+		if fs.config.MaxBytes > 0 && fs.curBytes+int64(len(line)) > fs.config.MaxBytes {
+			if err := fs.rotate(); err != nil {
+				return fmt.Errorf("rotating sink file: %w", err)
+			}
+		}
+
+		n, err := fs.f.Write(line)
+		if err != nil {
+			return fmt.Errorf("writing event: %w", err)
+		}
+		fs.curBytes += int64(n)
+	}
+
+	return nil
+}
+
+// rotate renames the current file to "<path>.1", truncating any previous
+// rotation, and opens a fresh file at the original path.
+func (fs *FileSink) rotate() error {
+	if err := fs.f.Close(); err != nil {
+		return err
+	}
+
+	rotated := fs.config.Path + ".1"
+	if err := os.Rename(fs.config.Path, rotated); err != nil {
+		return err
+	}
+
+	f, _, err := openAppend(fs.config.Path)
+	if err != nil {
+		return err
+	}
+
+	fs.f = f
+	fs.curBytes = 0
+	return nil
+}
@@ -0,0 +1,86 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFileSink(t *testing.T, path string, maxBytes int64) *FileSink {
+	t.Helper()
+
+	f, size, err := openAppend(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+
+	return &FileSink{
+		config:   FileCfg{Name: "test", Path: path, MaxBytes: maxBytes},
+		f:        f,
+		curBytes: size,
+	}
+}
+
+func TestFileSink_SendAppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	fs := newTestFileSink(t, path, 0)
+
+	err := fs.Send(context.Background(), &structs.Events{
+		Events: []structs.Event{{Topic: "Allocation"}, {Topic: "Deployment"}},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, fs.Close())
+	require.Equal(t, 2, countLines(t, path))
+}
+
+func TestFileSink_RotatesPastMaxBytes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	// Every marshaled event line is longer than 40 bytes, so each Send
+	// rotates the file before writing its event.
+	fs := newTestFileSink(t, path, 40)
+
+	for i := 0; i < 5; i++ {
+		err := fs.Send(context.Background(), &structs.Events{
+			Events: []structs.Event{{Topic: "Allocation"}},
+		})
+		require.NoError(t, err)
+	}
+	require.NoError(t, fs.Close())
+
+	_, err := os.Stat(path + ".1")
+	require.NoError(t, err, "expected a rotated file to exist")
+}
+
+func TestFileSink_ResumesByteCountAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	fs := newTestFileSink(t, path, 0)
+
+	require.NoError(t, fs.Send(context.Background(), &structs.Events{
+		Events: []structs.Event{{Topic: "Allocation"}},
+	}))
+	require.NoError(t, fs.Close())
+
+	reopened := newTestFileSink(t, path, 0)
+	require.Greater(t, reopened.curBytes, int64(0))
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	n := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		n++
+	}
+	require.NoError(t, scanner.Err())
+	return n
+}
@@ -0,0 +1,322 @@
+// Package filter implements a small, deterministic expression language for
+// matching events, e.g.:
+//
+//	Event.Topic == "Allocation" && Event.Payload.Allocation.ClientStatus == "failed"
+//
+// Expressions are parsed as Go expressions and evaluated against a single
+// event's fields via reflection. The evaluator is intentionally limited to
+// field access, comparisons, and boolean combinators: there is no function
+// calling, no loops, and no I/O, so a compiled Program is safe to run
+// per-event on the hot delivery path without risk of hanging or escaping
+// the sandbox.
+package filter
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+)
+
+// Program is a compiled filter expression ready to be evaluated against
+// events.
+type Program struct {
+	expr ast.Expr
+	src  string
+}
+
+// Compile parses expr into a Program. It returns an error if expr is not a
+// syntactically valid expression or uses constructs outside the supported
+// subset (identifiers, selectors, literals, comparisons, && / || / !).
+func Compile(expr string) (*Program, error) {
+	if expr == "" {
+		return nil, nil
+	}
+
+	node, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing filter expression: %w", err)
+	}
+
+	if err := validate(node); err != nil {
+		return nil, fmt.Errorf("invalid filter expression: %w", err)
+	}
+
+	return &Program{expr: node, src: expr}, nil
+}
+
+// String returns the original expression text.
+func (p *Program) String() string {
+	if p == nil {
+		return ""
+	}
+	return p.src
+}
+
+// Eval evaluates the compiled expression against event, which is exposed to
+// the expression as the "Event" identifier. A nil Program always matches.
+func (p *Program) Eval(event interface{}) (bool, error) {
+	if p == nil {
+		return true, nil
+	}
+
+	v, err := eval(p.expr, event)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("filter expression did not evaluate to a boolean")
+	}
+
+	return b, nil
+}
+
+// validate walks node and rejects any construct outside the supported
+// subset, so a compiled Program can never call functions, index slices, or
+// otherwise perform unbounded work.
+func validate(node ast.Expr) error {
+	switch n := node.(type) {
+	case *ast.Ident, *ast.BasicLit:
+		return nil
+	case *ast.SelectorExpr:
+		return validate(n.X)
+	case *ast.ParenExpr:
+		return validate(n.X)
+	case *ast.UnaryExpr:
+		if n.Op != token.NOT {
+			return fmt.Errorf("unsupported unary operator %q", n.Op)
+		}
+		return validate(n.X)
+	case *ast.BinaryExpr:
+		switch n.Op {
+		case token.LAND, token.LOR,
+			token.EQL, token.NEQ,
+			token.LSS, token.LEQ, token.GTR, token.GEQ:
+		default:
+			return fmt.Errorf("unsupported binary operator %q", n.Op)
+		}
+		if err := validate(n.X); err != nil {
+			return err
+		}
+		return validate(n.Y)
+	default:
+		return fmt.Errorf("unsupported expression of type %T", node)
+	}
+}
+
+// eval recursively evaluates node. "Event" resolves to the root value
+// passed to Program.Eval; any other top-level identifier is an error.
+func eval(node ast.Expr, event interface{}) (interface{}, error) {
+	switch n := node.(type) {
+	case *ast.Ident:
+		if n.Name == "Event" {
+			return event, nil
+		}
+		return nil, fmt.Errorf("unknown identifier %q", n.Name)
+
+	case *ast.BasicLit:
+		return literalValue(n)
+
+	case *ast.ParenExpr:
+		return eval(n.X, event)
+
+	case *ast.SelectorExpr:
+		base, err := eval(n.X, event)
+		if err != nil {
+			return nil, err
+		}
+		return fieldValue(base, n.Sel.Name)
+
+	case *ast.UnaryExpr: // token.NOT, enforced by validate
+		v, err := eval(n.X, event)
+		if err != nil {
+			return nil, err
+		}
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operand of ! must be boolean")
+		}
+		return !b, nil
+
+	case *ast.BinaryExpr:
+		return evalBinary(n, event)
+
+	default:
+		return nil, fmt.Errorf("unsupported expression of type %T", node)
+	}
+}
+
+func evalBinary(n *ast.BinaryExpr, event interface{}) (interface{}, error) {
+	if n.Op == token.LAND || n.Op == token.LOR {
+		lhs, err := eval(n.X, event)
+		if err != nil {
+			return nil, err
+		}
+		lb, ok := lhs.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operands of %s must be boolean", n.Op)
+		}
+
+		if n.Op == token.LAND && !lb {
+			return false, nil
+		}
+		if n.Op == token.LOR && lb {
+			return true, nil
+		}
+
+		rhs, err := eval(n.Y, event)
+		if err != nil {
+			return nil, err
+		}
+		rb, ok := rhs.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operands of %s must be boolean", n.Op)
+		}
+		return rb, nil
+	}
+
+	lhs, err := eval(n.X, event)
+	if err != nil {
+		return nil, err
+	}
+	rhs, err := eval(n.Y, event)
+	if err != nil {
+		return nil, err
+	}
+
+	return compare(n.Op, lhs, rhs)
+}
+
+func literalValue(lit *ast.BasicLit) (interface{}, error) {
+	switch lit.Kind {
+	case token.STRING:
+		s, err := unquote(lit.Value)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case token.INT:
+		var i int64
+		if _, err := fmt.Sscanf(lit.Value, "%d", &i); err != nil {
+			return nil, fmt.Errorf("invalid integer literal %q: %w", lit.Value, err)
+		}
+		return i, nil
+	case token.FLOAT:
+		var f float64
+		if _, err := fmt.Sscanf(lit.Value, "%g", &f); err != nil {
+			return nil, fmt.Errorf("invalid float literal %q: %w", lit.Value, err)
+		}
+		return f, nil
+	default:
+		return nil, fmt.Errorf("unsupported literal kind %v", lit.Kind)
+	}
+}
+
+func unquote(s string) (string, error) {
+	if len(s) < 2 {
+		return "", fmt.Errorf("invalid string literal %q", s)
+	}
+	return s[1 : len(s)-1], nil
+}
+
+// fieldValue looks up name on base, following pointers and supporting both
+// struct fields and string-keyed maps (e.g. a Payload holding a
+// map[string]interface{}). A missing map key yields nil, not an error.
+func fieldValue(base interface{}, name string) (interface{}, error) {
+	v := reflect.ValueOf(base)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		f := v.FieldByName(name)
+		if !f.IsValid() {
+			return nil, fmt.Errorf("no such field %q", name)
+		}
+		return f.Interface(), nil
+	case reflect.Map:
+		mv := v.MapIndex(reflect.ValueOf(name))
+		if !mv.IsValid() {
+			return nil, nil
+		}
+		return mv.Interface(), nil
+	default:
+		return nil, fmt.Errorf("cannot access field %q on %s", name, v.Kind())
+	}
+}
+
+// compare implements ==, !=, <, <=, >, >= across strings and numeric types.
+// A nil operand (e.g. a missing map key) equals only another nil; ordering
+// comparisons against nil are an error.
+func compare(op token.Token, lhs, rhs interface{}) (interface{}, error) {
+	if lhs == nil || rhs == nil {
+		switch op {
+		case token.EQL:
+			return lhs == nil && rhs == nil, nil
+		case token.NEQ:
+			return !(lhs == nil && rhs == nil), nil
+		}
+		return nil, fmt.Errorf("cannot compare %T and %T", lhs, rhs)
+	}
+
+	ls, lok := lhs.(string)
+	rs, rok := rhs.(string)
+	if lok && rok {
+		switch op {
+		case token.EQL:
+			return ls == rs, nil
+		case token.NEQ:
+			return ls != rs, nil
+		case token.LSS:
+			return ls < rs, nil
+		case token.LEQ:
+			return ls <= rs, nil
+		case token.GTR:
+			return ls > rs, nil
+		case token.GEQ:
+			return ls >= rs, nil
+		}
+	}
+
+	lf, lerr := toFloat(lhs)
+	rf, rerr := toFloat(rhs)
+	if lerr == nil && rerr == nil {
+		switch op {
+		case token.EQL:
+			return lf == rf, nil
+		case token.NEQ:
+			return lf != rf, nil
+		case token.LSS:
+			return lf < rf, nil
+		case token.LEQ:
+			return lf <= rf, nil
+		case token.GTR:
+			return lf > rf, nil
+		case token.GEQ:
+			return lf >= rf, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot compare %T and %T", lhs, rhs)
+}
+
+func toFloat(v interface{}) (float64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	default:
+		return 0, fmt.Errorf("%T is not numeric", v)
+	}
+}
@@ -0,0 +1,113 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testEvent struct {
+	Topic   string
+	Key     string
+	Index   int64
+	Payload map[string]interface{}
+}
+
+func TestCompile_RejectsUnsupportedConstructs(t *testing.T) {
+	cases := []string{
+		`len(Event.Topic)`,
+		`Event.Topic[0]`,
+		`Event.Topic + "x"`,
+		`func() bool { return true }()`,
+		`Event.Topic == "x" |`,
+	}
+
+	for _, expr := range cases {
+		t.Run(expr, func(t *testing.T) {
+			_, err := Compile(expr)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestCompile_Empty(t *testing.T) {
+	prog, err := Compile("")
+	require.NoError(t, err)
+	require.Nil(t, prog)
+
+	match, err := prog.Eval(&testEvent{})
+	require.NoError(t, err)
+	require.True(t, match)
+}
+
+func TestEval_FieldComparisons(t *testing.T) {
+	event := &testEvent{Topic: "Allocation", Key: "abc", Index: 42}
+
+	cases := []struct {
+		expr  string
+		match bool
+	}{
+		{`Event.Topic == "Allocation"`, true},
+		{`Event.Topic == "Deployment"`, false},
+		{`Event.Topic != "Deployment"`, true},
+		{`Event.Index > 10`, true},
+		{`Event.Index >= 42`, true},
+		{`Event.Index < 10`, false},
+		{`Event.Topic == "Allocation" && Event.Index > 10`, true},
+		{`Event.Topic == "Deployment" || Event.Index > 10`, true},
+		{`!(Event.Topic == "Deployment")`, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.expr, func(t *testing.T) {
+			prog, err := Compile(c.expr)
+			require.NoError(t, err)
+
+			match, err := prog.Eval(event)
+			require.NoError(t, err)
+			require.Equal(t, c.match, match)
+		})
+	}
+}
+
+func TestEval_MapPayload(t *testing.T) {
+	event := &testEvent{
+		Topic: "Allocation",
+		Payload: map[string]interface{}{
+			"ClientStatus": "failed",
+		},
+	}
+
+	prog, err := Compile(`Event.Payload.ClientStatus == "failed"`)
+	require.NoError(t, err)
+
+	match, err := prog.Eval(event)
+	require.NoError(t, err)
+	require.True(t, match)
+}
+
+func TestEval_UnknownFieldErrors(t *testing.T) {
+	prog, err := Compile(`Event.NoSuchField == "x"`)
+	require.NoError(t, err)
+
+	_, err = prog.Eval(&testEvent{})
+	require.Error(t, err)
+}
+
+func TestEval_MissingMapKeyIsNilNotError(t *testing.T) {
+	event := &testEvent{Payload: map[string]interface{}{}}
+
+	prog, err := Compile(`Event.Payload.Missing == "x"`)
+	require.NoError(t, err)
+
+	_, err = prog.Eval(event)
+	require.NoError(t, err)
+}
+
+func TestEval_NonBooleanExpressionErrors(t *testing.T) {
+	prog, err := Compile(`Event.Topic`)
+	require.NoError(t, err)
+
+	_, err = prog.Eval(&testEvent{Topic: "Allocation"})
+	require.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// Format selects the wire encoding WebhookSink uses for a delivery.
+type Format string
+
+const (
+	// FormatNomad is the original encoding: the raw structs.Events
+	// struct as a single JSON document.
+	FormatNomad Format = "nomad"
+
+	// FormatCloudEventsBatch encodes each event as a CloudEvents 1.0
+	// envelope and sends the batch as a JSON array.
+	FormatCloudEventsBatch Format = "cloudevents-batch"
+
+	// FormatNDJSON encodes each event as its own line of JSON, with no
+	// enclosing array, for streaming consumers.
+	FormatNDJSON Format = "ndjson"
+)
+
+// contentType returns the Content-Type header value for f.
+func (f Format) contentType() string {
+	switch f {
+	case FormatCloudEventsBatch:
+		return "application/cloudevents-batch+json"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/json"
+	}
+}
+
+// cloudEvent is a CloudEvents 1.0 envelope, see
+// https://github.com/cloudevents/spec/blob/main/cloudevents/spec.md
+type cloudEvent struct {
+	SpecVersion string      `json:"specversion"`
+	Type        string      `json:"type"`
+	Source      string      `json:"source"`
+	ID          string      `json:"id"`
+	Time        string      `json:"time"`
+	Data        interface{} `json:"data"`
+}
+
+// encodeBody renders e in the given format, returning the encoded bytes.
+// source identifies this cluster/region for the CloudEvents "source" field.
+func encodeBody(e *structs.Events, format Format, source string) ([]byte, error) {
+	switch format {
+	case FormatCloudEventsBatch:
+		return encodeCloudEventsBatch(e, source)
+	case FormatNDJSON:
+		return encodeNDJSON(e)
+	default:
+		buf := bytes.NewBuffer(nil)
+		if err := json.NewEncoder(buf).Encode(e); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+func encodeCloudEventsBatch(e *structs.Events, source string) ([]byte, error) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	batch := make([]cloudEvent, 0, len(e.Events))
+	for _, event := range e.Events {
+		batch = append(batch, cloudEvent{
+			SpecVersion: "1.0",
+			Type:        fmt.Sprintf("io.nomad.%s.%s", event.Topic, event.Type),
+			Source:      source,
+			ID:          fmt.Sprintf("%d-%s", e.Index, event.Key),
+			Time:        now,
+			Data:        event.Payload,
+		})
+	}
+
+	return json.Marshal(batch)
+}
+
+func encodeNDJSON(e *structs.Events) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	enc := json.NewEncoder(buf)
+	for _, event := range e.Events {
+		if err := enc.Encode(event); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
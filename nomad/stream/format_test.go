@@ -0,0 +1,82 @@
+package stream
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvents() *structs.Events {
+	return &structs.Events{
+		Index: 7,
+		Events: []structs.Event{
+			{Topic: "Allocation", Type: "AllocationUpdated", Key: "alloc-1", Payload: "p1"},
+			{Topic: "Deployment", Type: "DeploymentUpdated", Key: "dep-1", Payload: "p2"},
+		},
+	}
+}
+
+func TestFormat_ContentType(t *testing.T) {
+	require.Equal(t, "application/json", FormatNomad.contentType())
+	require.Equal(t, "application/cloudevents-batch+json", FormatCloudEventsBatch.contentType())
+	require.Equal(t, "application/x-ndjson", FormatNDJSON.contentType())
+	require.Equal(t, "application/json", Format("unknown").contentType())
+}
+
+func TestEncodeBody_Nomad(t *testing.T) {
+	body, err := encodeBody(testEvents(), FormatNomad, "")
+	require.NoError(t, err)
+
+	var decoded structs.Events
+	require.NoError(t, json.Unmarshal(body, &decoded))
+	require.Equal(t, uint64(7), decoded.Index)
+	require.Len(t, decoded.Events, 2)
+}
+
+func TestEncodeBody_CloudEventsBatch(t *testing.T) {
+	body, err := encodeBody(testEvents(), FormatCloudEventsBatch, "nomad://region1")
+	require.NoError(t, err)
+
+	var batch []map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &batch))
+	require.Len(t, batch, 2)
+
+	require.Equal(t, "1.0", batch[0]["specversion"])
+	require.Equal(t, "io.nomad.Allocation.AllocationUpdated", batch[0]["type"])
+	require.Equal(t, "nomad://region1", batch[0]["source"])
+	require.Equal(t, "7-alloc-1", batch[0]["id"])
+}
+
+func TestEncodeBody_NDJSON(t *testing.T) {
+	body, err := encodeBody(testEvents(), FormatNDJSON, "")
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	require.Len(t, lines, 2)
+
+	var first structs.Event
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.Equal(t, structs.Topic("Allocation"), first.Topic)
+}
+
+func TestGzipCompress_RoundTrips(t *testing.T) {
+	original := []byte(strings.Repeat("hello nomad ", 100))
+
+	compressed, err := gzipCompress(original)
+	require.NoError(t, err)
+	require.Less(t, len(compressed), len(original))
+
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	require.NoError(t, err)
+	defer r.Close()
+
+	decompressed, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, original, decompressed)
+}
@@ -0,0 +1,136 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	kafka "github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// KafkaCfg configures a KafkaSink.
+type KafkaCfg struct {
+	// Name uniquely identifies this sink and is used as the checkpoint
+	// store key.
+	Name string
+
+	// Brokers is the list of "host:port" bootstrap brokers.
+	Brokers []string
+
+	// Topic is the Kafka topic events are produced to.
+	Topic string
+
+	// Acks controls producer acknowledgement semantics ("0", "1", or
+	// "all"). Defaults to "all".
+	Acks string
+}
+
+func applyKafkaDefaults(cfg *KafkaCfg) {
+	if cfg.Acks == "" {
+		cfg.Acks = "all"
+	}
+}
+
+// KafkaSink is a Sink that produces events to a Kafka topic, partitioned by
+// the event's Topic+Key so that all events for the same resource land on
+// the same partition and are ordered relative to one another.
+type KafkaSink struct {
+	config   KafkaCfg
+	producer *kafka.Producer
+}
+
+// NewKafkaSink constructs a KafkaSink and wraps it in a SinkRunner
+// subscribed to broker per subReq.
+func NewKafkaSink(cfg *KafkaCfg, runnerCfg *RunnerCfg, broker *EventBroker, subReq *SubscribeRequest) (*SinkRunner, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("kafka sink requires at least one broker")
+	}
+	if cfg.Topic == "" {
+		return nil, fmt.Errorf("kafka sink requires a topic")
+	}
+	applyKafkaDefaults(cfg)
+
+	producer, err := kafka.NewProducer(&kafka.ConfigMap{
+		"bootstrap.servers": joinBrokers(cfg.Brokers),
+		"acks":              cfg.Acks,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating kafka producer: %w", err)
+	}
+
+	ks := &KafkaSink{
+		config:   *cfg,
+		producer: producer,
+	}
+
+	return NewSinkRunner(ks, runnerCfg, broker, subReq)
+}
+
+func (ks *KafkaSink) Name() string {
+	return ks.config.Name
+}
+
+func (ks *KafkaSink) Type() string {
+	return "kafka"
+}
+
+func (ks *KafkaSink) Close() error {
+	ks.producer.Close()
+	return nil
+}
+
+// Send produces one Kafka message per event in e, partitioned by
+// "<topic>/<key>" so related events stay ordered. Each Produce call gets
+// its own buffered, disposable delivery channel rather than sharing one
+// across the whole Send (or across calls): if ctx were canceled while a
+// report is still in flight on a shared channel, the next Send's read on
+// that same channel could consume the stale report and misattribute its
+// success/failure to an unrelated event. A per-call channel, sized so the
+// delivery-report goroutine never blocks writing to it, is simply
+// abandoned (and garbage collected) if Send returns before the report
+// arrives — it is never closed, so there is no risk of a late write
+// panicking.
+func (ks *KafkaSink) Send(ctx context.Context, e *structs.Events) error {
+	for _, event := range e.Events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("marshaling event: %w", err)
+		}
+
+		deliveryCh := make(chan kafka.Event, 1)
+
+		partitionKey := fmt.Sprintf("%s/%s", event.Topic, event.Key)
+		err = ks.producer.Produce(&kafka.Message{
+			TopicPartition: kafka.TopicPartition{Topic: &ks.config.Topic, Partition: kafka.PartitionAny},
+			Key:            []byte(partitionKey),
+			Value:          payload,
+		}, deliveryCh)
+		if err != nil {
+			return fmt.Errorf("producing kafka message: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev := <-deliveryCh:
+			m, ok := ev.(*kafka.Message)
+			if !ok {
+				continue
+			}
+			if m.TopicPartition.Error != nil {
+				return fmt.Errorf("kafka delivery failed: %w", m.TopicPartition.Error)
+			}
+		}
+	}
+
+	return nil
+}
+
+func joinBrokers(brokers []string) string {
+	out := brokers[0]
+	for _, b := range brokers[1:] {
+		out += "," + b
+	}
+	return out
+}
@@ -0,0 +1,22 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoinBrokers(t *testing.T) {
+	require.Equal(t, "a:9092", joinBrokers([]string{"a:9092"}))
+	require.Equal(t, "a:9092,b:9092", joinBrokers([]string{"a:9092", "b:9092"}))
+}
+
+func TestApplyKafkaDefaults(t *testing.T) {
+	cfg := &KafkaCfg{}
+	applyKafkaDefaults(cfg)
+	require.Equal(t, "all", cfg.Acks)
+
+	cfg = &KafkaCfg{Acks: "1"}
+	applyKafkaDefaults(cfg)
+	require.Equal(t, "1", cfg.Acks)
+}
@@ -0,0 +1,104 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/nats-io/nats.go"
+)
+
+// NATSCfg configures a NATSSink.
+type NATSCfg struct {
+	// Name uniquely identifies this sink and is used as the checkpoint
+	// store key.
+	Name string
+
+	// URL is the NATS server URL, e.g. "nats://127.0.0.1:4222".
+	URL string
+
+	// SubjectPrefix is prepended to the event's topic to form the
+	// publish subject, e.g. prefix "nomad.events" + topic "Allocation"
+	// -> "nomad.events.Allocation".
+	SubjectPrefix string
+}
+
+func applyNATSDefaults(cfg *NATSCfg) {
+	if cfg.SubjectPrefix == "" {
+		cfg.SubjectPrefix = "nomad.events"
+	}
+}
+
+// NATSSink is a Sink that publishes events to NATS JetStream, deriving the
+// subject from the event's topic.
+type NATSSink struct {
+	config NATSCfg
+	conn   *nats.Conn
+	js     nats.JetStreamContext
+}
+
+// NewNATSSink constructs a NATSSink and wraps it in a SinkRunner subscribed
+// to broker per subReq.
+func NewNATSSink(cfg *NATSCfg, runnerCfg *RunnerCfg, broker *EventBroker, subReq *SubscribeRequest) (*SinkRunner, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("nats sink requires a url")
+	}
+	applyNATSDefaults(cfg)
+
+	conn, err := nats.Connect(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to nats: %w", err)
+	}
+
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("acquiring jetstream context: %w", err)
+	}
+
+	ns := &NATSSink{
+		config: *cfg,
+		conn:   conn,
+		js:     js,
+	}
+
+	return NewSinkRunner(ns, runnerCfg, broker, subReq)
+}
+
+func (ns *NATSSink) Name() string {
+	return ns.config.Name
+}
+
+func (ns *NATSSink) Type() string {
+	return "nats"
+}
+
+func (ns *NATSSink) Close() error {
+	ns.conn.Close()
+	return nil
+}
+
+// subject returns the JetStream subject for topic, e.g. "nomad.events.Allocation".
+func (ns *NATSSink) subject(topic structs.Topic) string {
+	return strings.Join([]string{ns.config.SubjectPrefix, string(topic)}, ".")
+}
+
+// Send publishes one JetStream message per event, each to a subject derived
+// from the event's topic.
+func (ns *NATSSink) Send(ctx context.Context, e *structs.Events) error {
+	for _, event := range e.Events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("marshaling event: %w", err)
+		}
+
+		_, err = ns.js.Publish(ns.subject(event.Topic), payload, nats.Context(ctx))
+		if err != nil {
+			return fmt.Errorf("publishing to nats: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyNATSDefaults(t *testing.T) {
+	cfg := &NATSCfg{}
+	applyNATSDefaults(cfg)
+	require.Equal(t, "nomad.events", cfg.SubjectPrefix)
+
+	cfg = &NATSCfg{SubjectPrefix: "custom.prefix"}
+	applyNATSDefaults(cfg)
+	require.Equal(t, "custom.prefix", cfg.SubjectPrefix)
+}
+
+func TestNATSSink_Subject(t *testing.T) {
+	ns := &NATSSink{config: NATSCfg{SubjectPrefix: "nomad.events"}}
+	require.Equal(t, "nomad.events.Allocation", ns.subject(structs.Topic("Allocation")))
+
+	ns = &NATSSink{config: NATSCfg{SubjectPrefix: "custom.prefix"}}
+	require.Equal(t, "custom.prefix.Deployment", ns.subject(structs.Topic("Deployment")))
+}
@@ -0,0 +1,123 @@
+package stream
+
+import (
+	"sync"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Observability lets embedders inject their own OTel tracer provider,
+// context propagator, and Prometheus registerer for the sink pipeline,
+// instead of relying on the process-wide otel globals and the Prometheus
+// default registry.
+type Observability struct {
+	TracerProvider trace.TracerProvider
+	Propagator     propagation.TextMapPropagator
+
+	// Registerer is where sink pipeline metrics are registered. Defaults
+	// to prometheus.DefaultRegisterer.
+	Registerer prometheus.Registerer
+
+	metricsOnce sync.Once
+	metricVecs  *sinkMetricVecs
+}
+
+func (o *Observability) tracer() trace.Tracer {
+	tp := otel.GetTracerProvider()
+	if o != nil && o.TracerProvider != nil {
+		tp = o.TracerProvider
+	}
+	return tp.Tracer("github.com/hashicorp/nomad/nomad/stream")
+}
+
+func (o *Observability) propagator() propagation.TextMapPropagator {
+	if o != nil && o.Propagator != nil {
+		return o.Propagator
+	}
+	return otel.GetTextMapPropagator()
+}
+
+// defaultSinkMetricVecs is the sinkMetricVecs shared by every sink that
+// doesn't have its own Observability (or has one with no Registerer set),
+// all of which fall back to prometheus.DefaultRegisterer. It must be
+// memoized process-wide rather than per-Observability: a nil Observability
+// has nowhere to hang a sync.Once of its own, and registering the same
+// metric names against the default registry twice (e.g. a second sink
+// constructed without an explicit shared Observability) panics.
+var (
+	defaultMetricVecsOnce sync.Once
+	defaultMetricVecs     *sinkMetricVecs
+)
+
+// metrics returns the Prometheus metric vectors sink pipelines record to,
+// registering them against o.Registerer (or the default registry) the
+// first time it's called. Safe to call on a nil Observability.
+func (o *Observability) metrics() *sinkMetricVecs {
+	if o == nil || o.Registerer == nil {
+		defaultMetricVecsOnce.Do(func() {
+			defaultMetricVecs = newSinkMetricVecs(prometheus.DefaultRegisterer)
+		})
+		return defaultMetricVecs
+	}
+
+	o.metricsOnce.Do(func() {
+		o.metricVecs = newSinkMetricVecs(o.Registerer)
+	})
+	return o.metricVecs
+}
+
+// sinkMetricVecs holds the Prometheus metrics for a sink pipeline, labeled
+// by sink name (and topic or outcome where useful).
+type sinkMetricVecs struct {
+	eventsTotal    *prometheus.CounterVec
+	sendDuration   *prometheus.HistogramVec
+	retriesTotal   *prometheus.CounterVec
+	bufferDepth    *prometheus.GaugeVec
+	lastAckedIndex *prometheus.GaugeVec
+}
+
+func newSinkMetricVecs(reg prometheus.Registerer) *sinkMetricVecs {
+	factory := promauto.With(reg)
+	return &sinkMetricVecs{
+		eventsTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "nomad_stream_sink_events_total",
+			Help: "Total number of events successfully delivered by a sink.",
+		}, []string{"sink", "topic"}),
+
+		sendDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "nomad_stream_sink_send_duration_seconds",
+			Help: "Duration of a single sink delivery attempt.",
+		}, []string{"sink", "outcome"}),
+
+		retriesTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "nomad_stream_sink_retries_total",
+			Help: "Total number of delivery retries issued for a sink.",
+		}, []string{"sink"}),
+
+		bufferDepth: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "nomad_stream_sink_buffer_depth",
+			Help: "Number of event batches currently buffered for a sink.",
+		}, []string{"sink"}),
+
+		lastAckedIndex: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "nomad_stream_sink_last_acked_index",
+			Help: "Last events.Index successfully delivered and checkpointed by a sink.",
+		}, []string{"sink"}),
+	}
+}
+
+// eventCountsByTopic tallies the number of events per topic in e, used to
+// label sinkMetricVecs.eventsTotal. Sinks typically subscribe to a handful
+// of topics, so cardinality stays low.
+func eventCountsByTopic(e *structs.Events) map[string]int {
+	counts := make(map[string]int, 1)
+	for _, event := range e.Events {
+		counts[string(event.Topic)]++
+	}
+	return counts
+}
@@ -0,0 +1,95 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestObservability_MetricsRegisterAgainstInjectedRegisterer(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	o := &Observability{Registerer: reg}
+
+	vecs := o.metrics()
+	require.NotNil(t, vecs)
+
+	vecs.eventsTotal.WithLabelValues("sink", "topic").Inc()
+
+	metricFamilies, err := reg.Gather()
+	require.NoError(t, err)
+
+	var found bool
+	for _, mf := range metricFamilies {
+		if mf.GetName() == "nomad_stream_sink_events_total" {
+			found = true
+		}
+	}
+	require.True(t, found, "expected metrics to be registered against the injected registerer")
+}
+
+func TestObservability_MetricsMemoizedPerInstance(t *testing.T) {
+	o := &Observability{Registerer: prometheus.NewRegistry()}
+
+	first := o.metrics()
+	second := o.metrics()
+	require.Same(t, first, second)
+}
+
+func TestObservability_MetricsIsolatedAcrossInstances(t *testing.T) {
+	a := &Observability{Registerer: prometheus.NewRegistry()}
+	b := &Observability{Registerer: prometheus.NewRegistry()}
+
+	require.NotSame(t, a.metrics(), b.metrics())
+}
+
+func TestObservability_NilFallsBackToDefaultRegisterer(t *testing.T) {
+	var o *Observability
+	require.NotPanics(t, func() {
+		o.metrics()
+	})
+}
+
+// TestObservability_MultipleSinksWithoutExplicitObservabilityDontPanic
+// guards against re-registering the same metric names against
+// prometheus.DefaultRegisterer for every sink that doesn't share an
+// Observability: promauto panics on a duplicate registration, which would
+// otherwise crash the agent the moment a second sink (of any type) is
+// constructed without one.
+func TestObservability_MultipleSinksWithoutExplicitObservabilityDontPanic(t *testing.T) {
+	var webhookObservability *Observability
+	kafkaObservability := &Observability{}
+
+	require.NotPanics(t, func() {
+		webhookObservability.metrics()
+		kafkaObservability.metrics()
+		(&Observability{}).metrics()
+	})
+}
+
+func TestObservability_PropagatorPrefersConfigured(t *testing.T) {
+	custom := propagation.TraceContext{}
+	o := &Observability{Propagator: custom}
+
+	require.Equal(t, custom, o.propagator())
+}
+
+func TestObservability_PropagatorFallsBackWhenNil(t *testing.T) {
+	var o *Observability
+	require.NotNil(t, o.propagator())
+}
+
+func TestEventCountsByTopic(t *testing.T) {
+	events := &structs.Events{
+		Events: []structs.Event{
+			{Topic: "Allocation"},
+			{Topic: "Allocation"},
+			{Topic: "Deployment"},
+		},
+	}
+
+	counts := eventCountsByTopic(events)
+	require.Equal(t, map[string]int{"Allocation": 2, "Deployment": 1}, counts)
+}
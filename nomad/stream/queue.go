@@ -0,0 +1,86 @@
+package stream
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// OverflowPolicy controls what an eventQueue does when it is full and a new
+// batch of events arrives.
+type OverflowPolicy string
+
+const (
+	// OverflowDropOldest discards the oldest queued batch to make room for
+	// the new one.
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+
+	// OverflowBlock applies backpressure by blocking the producer until
+	// room is available or the context is canceled.
+	OverflowBlock OverflowPolicy = "block"
+)
+
+// eventQueue is a bounded, FIFO buffer of event batches sitting between the
+// broker subscription and the sink's sender, so that a transient outage on
+// the sender side doesn't stall the broker.
+type eventQueue struct {
+	policy OverflowPolicy
+	ch     chan *structs.Events
+
+	dropped *uint64
+}
+
+func newEventQueue(size int, policy OverflowPolicy, dropped *uint64) *eventQueue {
+	if size <= 0 {
+		size = 1
+	}
+
+	return &eventQueue{
+		policy:  policy,
+		ch:      make(chan *structs.Events, size),
+		dropped: dropped,
+	}
+}
+
+// Push enqueues events, applying the configured OverflowPolicy if the queue
+// is full. Push never blocks under OverflowDropOldest. Under OverflowBlock
+// it blocks until room is available or ctx is done, returning ctx.Err() in
+// the latter case so a caller stuck here on shutdown can unwind instead of
+// leaking forever.
+func (q *eventQueue) Push(ctx context.Context, events *structs.Events) error {
+	switch q.policy {
+	case OverflowDropOldest:
+		for {
+			select {
+			case q.ch <- events:
+				return nil
+			default:
+			}
+
+			select {
+			case <-q.ch:
+				if q.dropped != nil {
+					atomic.AddUint64(q.dropped, 1)
+				}
+			default:
+			}
+		}
+	default: // OverflowBlock
+		select {
+		case q.ch <- events:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (q *eventQueue) Chan() <-chan *structs.Events {
+	return q.ch
+}
+
+// Len returns the number of batches currently buffered.
+func (q *eventQueue) Len() int {
+	return len(q.ch)
+}
@@ -0,0 +1,68 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventQueue_DropOldestNeverBlocks(t *testing.T) {
+	var dropped uint64
+	q := newEventQueue(2, OverflowDropOldest, &dropped)
+
+	for i := 0; i < 5; i++ {
+		err := q.Push(context.Background(), &structs.Events{Index: uint64(i)})
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 2, q.Len())
+	require.Equal(t, uint64(3), dropped)
+}
+
+func TestEventQueue_BlockPolicyBlocksWhenFull(t *testing.T) {
+	q := newEventQueue(1, OverflowBlock, nil)
+
+	require.NoError(t, q.Push(context.Background(), &structs.Events{Index: 1}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := q.Push(ctx, &structs.Events{Index: 2})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestEventQueue_BlockPolicyUnblocksOnRoom(t *testing.T) {
+	q := newEventQueue(1, OverflowBlock, nil)
+	require.NoError(t, q.Push(context.Background(), &structs.Events{Index: 1}))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- q.Push(context.Background(), &structs.Events{Index: 2})
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Push returned before the queue had room")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	<-q.Chan()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Push did not unblock after a slot freed up")
+	}
+}
+
+func TestEventQueue_Len(t *testing.T) {
+	q := newEventQueue(4, OverflowDropOldest, nil)
+	require.Equal(t, 0, q.Len())
+
+	require.NoError(t, q.Push(context.Background(), &structs.Events{Index: 1}))
+	require.Equal(t, 1, q.Len())
+}
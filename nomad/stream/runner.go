@@ -0,0 +1,464 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad/nomad/stream/filter"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultMaxRetries     = 0 // retry forever
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxBackoff     = 30 * time.Second
+	defaultBufferSize     = 1024
+)
+
+// SinkMetrics holds point-in-time counters for a single running sink.
+type SinkMetrics struct {
+	// Inflight is the number of event batches currently queued or being
+	// delivered.
+	Inflight uint64
+
+	// Retries counts delivery attempts beyond the first for this sink.
+	Retries uint64
+
+	// Dropped counts event batches discarded due to queue overflow.
+	Dropped uint64
+
+	// LastAckedIndex is the most recent events.Index successfully
+	// delivered and checkpointed.
+	LastAckedIndex uint64
+}
+
+// RunnerCfg holds the configuration shared by every Sink implementation:
+// retry behavior, buffering, and checkpointing. Sink-specific configuration
+// (addresses, credentials, topics, ...) lives on each Sink's own config
+// type instead.
+type RunnerCfg struct {
+	// MaxRetries bounds the number of retries after the first failed
+	// delivery attempt for a single event batch before it is given up on,
+	// so a batch is attempted at most MaxRetries+1 times. Zero means retry
+	// indefinitely.
+	MaxRetries int
+
+	// InitialBackoff is the base delay before the first retry; jitter
+	// scales each delay to between half and all of its computed value.
+	// Defaults to 500ms.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the exponential backoff delay. Defaults to 30s.
+	MaxBackoff time.Duration
+
+	// BufferSize bounds the number of event batches buffered between the
+	// broker subscription and the sink. Defaults to 1024.
+	BufferSize int
+
+	// OverflowPolicy controls behavior once the buffer is full. Defaults
+	// to OverflowBlock.
+	OverflowPolicy OverflowPolicy
+
+	// CheckpointStore, if set, is used to persist and resume the last
+	// acked index across restarts. If nil, the runner always starts from
+	// index 0.
+	CheckpointStore *CheckpointStore
+
+	// Filter, if set, is evaluated per-event between the broker
+	// subscription and the sink; events it rejects are dropped without
+	// being delivered. The broker index is still checkpointed so that a
+	// restart does not re-fetch events that were filtered out, not
+	// missed.
+	Filter *filter.Program
+
+	// BatchWindow, if non-zero, coalesces event batches arriving within
+	// this window (or until BatchMaxBytes/BatchMaxEvents is hit,
+	// whichever first) into a single call to Sink.Send, so that a burst
+	// of small broker batches doesn't turn into a burst of small
+	// deliveries. Zero disables batching: every batch is sent as soon as
+	// it's received.
+	BatchWindow time.Duration
+
+	// BatchMaxBytes flushes a coalesced batch once the summed JSON-encoded
+	// size of its queued batches reaches this many bytes. Queued batches
+	// are appended whole, so a flushed batch can exceed it. Zero means no
+	// byte limit.
+	BatchMaxBytes int
+
+	// BatchMaxEvents flushes a coalesced batch once it holds at least this
+	// many events. Queued batches are appended whole, so a flushed batch
+	// can exceed it. Zero means no event-count limit.
+	BatchMaxEvents int
+
+	// Observability configures the OTel tracer provider and propagator
+	// used to trace and annotate deliveries, and the Prometheus
+	// registerer sink metrics are registered against. A nil Observability
+	// (or a nil field on it) falls back to the otel package globals and
+	// prometheus.DefaultRegisterer.
+	Observability *Observability
+}
+
+func defaultRunnerCfg() *RunnerCfg {
+	return &RunnerCfg{
+		MaxRetries:     defaultMaxRetries,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     defaultMaxBackoff,
+		BufferSize:     defaultBufferSize,
+		OverflowPolicy: OverflowBlock,
+	}
+}
+
+// applyRunnerDefaults fills any zero-valued fields on cfg with the package
+// defaults, without clobbering explicit operator configuration.
+func applyRunnerDefaults(cfg *RunnerCfg) {
+	def := defaultRunnerCfg()
+
+	if cfg.InitialBackoff == 0 {
+		cfg.InitialBackoff = def.InitialBackoff
+	}
+	if cfg.MaxBackoff == 0 {
+		cfg.MaxBackoff = def.MaxBackoff
+	}
+	if cfg.BufferSize == 0 {
+		cfg.BufferSize = def.BufferSize
+	}
+	if cfg.OverflowPolicy == "" {
+		cfg.OverflowPolicy = def.OverflowPolicy
+	}
+}
+
+// SinkRunner drives the subscribe -> queue -> send -> checkpoint pipeline
+// for a Sink, so that retry/backoff/checkpoint logic is written once and
+// shared by every Sink implementation.
+type SinkRunner struct {
+	sink   Sink
+	config RunnerCfg
+
+	subscription *Subscription
+	queue        *eventQueue
+
+	// lastIndex should be accessed atomically
+	lastIndex uint64
+
+	metrics    SinkMetrics
+	metricVecs *sinkMetricVecs
+
+	l hclog.Logger
+}
+
+// NewSinkRunner subscribes to broker per subReq (resuming from the
+// checkpointed index if cfg.CheckpointStore has one for this sink) and
+// returns a SinkRunner ready to be started.
+func NewSinkRunner(sink Sink, cfg *RunnerCfg, broker *EventBroker, subReq *SubscribeRequest) (*SinkRunner, error) {
+	if cfg == nil {
+		cfg = defaultRunnerCfg()
+	} else {
+		applyRunnerDefaults(cfg)
+	}
+
+	r := &SinkRunner{
+		sink:       sink,
+		config:     *cfg,
+		metricVecs: cfg.Observability.metrics(),
+		l:          hclog.L().Named("sink_runner").With("sink", sink.Name()),
+	}
+	r.queue = newEventQueue(cfg.BufferSize, cfg.OverflowPolicy, &r.metrics.Dropped)
+
+	if cfg.CheckpointStore != nil {
+		index, err := cfg.CheckpointStore.Load(sink.Name())
+		if err != nil {
+			return nil, fmt.Errorf("loading checkpoint for sink %q: %w", sink.Name(), err)
+		}
+		subReq.Index = index
+		r.lastIndex = index
+	}
+
+	sub, err := broker.Subscribe(subReq)
+	if err != nil {
+		return nil, fmt.Errorf("configuring sink subscription: %w", err)
+	}
+	r.subscription = sub
+
+	return r, nil
+}
+
+// Start runs the pipeline until ctx is canceled or the subscription is
+// closed. It never returns on delivery errors; those are retried with
+// backoff inside sendWithRetry.
+func (r *SinkRunner) Start(ctx context.Context) {
+	defer r.subscription.Unsubscribe()
+
+	go r.receiveLoop(ctx)
+	r.sendLoop(ctx)
+}
+
+// receiveLoop pulls event batches off the broker subscription and pushes
+// them onto the bounded queue, decoupling the broker from any slowness or
+// outage on the sink side.
+func (r *SinkRunner) receiveLoop(ctx context.Context) {
+	for {
+		events, err := r.subscription.Next(ctx)
+		if err != nil {
+			if err == ErrSubscriptionClosed {
+				r.l.Debug("subscription closed")
+			} else {
+				r.l.Error("error receiving events from subscription", "error", err)
+			}
+			return
+		}
+		if len(events.Events) == 0 {
+			continue
+		}
+
+		filtered := r.applyFilter(&events)
+		if len(filtered.Events) == 0 {
+			// Nothing matched, but the broker index still advanced: ack
+			// it directly so a restart resumes after these events rather
+			// than re-filtering them.
+			r.checkpoint(events.Index)
+			continue
+		}
+
+		atomic.AddUint64(&r.metrics.Inflight, 1)
+		if err := r.queue.Push(ctx, filtered); err != nil {
+			// ctx was canceled while blocked on a full queue (OverflowBlock):
+			// unwind instead of leaking this goroutine and its subscription.
+			atomic.AddUint64(&r.metrics.Inflight, ^uint64(0))
+			return
+		}
+		r.metricVecs.bufferDepth.WithLabelValues(r.sink.Name()).Set(float64(r.queue.Len()))
+	}
+}
+
+// applyFilter evaluates r.config.Filter against each event in events,
+// returning a copy containing only the events that matched. Events are
+// evaluated independently: one event's filter erroring (e.g. a field the
+// event's topic doesn't carry) only excludes that event, it does not drop
+// the rest of the batch. The returned Events retains the original broker
+// Index so the caller can still checkpoint correctly even when every event
+// was dropped or excluded.
+func (r *SinkRunner) applyFilter(events *structs.Events) *structs.Events {
+	if r.config.Filter == nil {
+		return events
+	}
+
+	kept := make([]structs.Event, 0, len(events.Events))
+	for _, event := range events.Events {
+		match, err := r.config.Filter.Eval(&event)
+		if err != nil {
+			r.l.Warn("error evaluating sink filter for event, excluding it", "error", err, "topic", event.Topic, "index", events.Index)
+			continue
+		}
+		if match {
+			kept = append(kept, event)
+		}
+	}
+
+	return &structs.Events{Index: events.Index, Events: kept}
+}
+
+// checkpoint records index as the last acked index without a delivery
+// having taken place, e.g. when every event in a batch was filtered out.
+func (r *SinkRunner) checkpoint(index uint64) {
+	atomic.StoreUint64(&r.lastIndex, index)
+	atomic.StoreUint64(&r.metrics.LastAckedIndex, index)
+
+	if r.config.CheckpointStore != nil {
+		if err := r.config.CheckpointStore.Save(r.sink.Name(), index); err != nil {
+			r.l.Error("failed to persist checkpoint", "error", err)
+		}
+	}
+}
+
+func (r *SinkRunner) sendLoop(ctx context.Context) {
+	if r.config.BatchWindow <= 0 {
+		r.sendLoopUnbatched(ctx)
+		return
+	}
+	r.sendLoopBatched(ctx)
+}
+
+func (r *SinkRunner) sendLoopUnbatched(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case events, ok := <-r.queue.Chan():
+			if !ok {
+				return
+			}
+			r.metricVecs.bufferDepth.WithLabelValues(r.sink.Name()).Set(float64(r.queue.Len()))
+			r.sendBatch(ctx, events, 1)
+		}
+	}
+}
+
+// sendLoopBatched coalesces consecutive queued batches into a single
+// delivery, flushing when BatchWindow elapses since the first batch in the
+// group, or when BatchMaxBytes/BatchMaxEvents is reached, whichever first.
+func (r *SinkRunner) sendLoopBatched(ctx context.Context) {
+	var pending *structs.Events
+	var pendingCount int
+	var pendingBytes int
+	var timer *time.Timer
+	var timerCh <-chan time.Time
+
+	flush := func() {
+		if pending == nil {
+			return
+		}
+		r.sendBatch(ctx, pending, pendingCount)
+		pending, pendingCount, pendingBytes = nil, 0, 0
+		if timer != nil {
+			timer.Stop()
+			timer, timerCh = nil, nil
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			flush()
+			return
+		case events, ok := <-r.queue.Chan():
+			if !ok {
+				flush()
+				return
+			}
+
+			if pending == nil {
+				pending = &structs.Events{}
+				timer = time.NewTimer(r.config.BatchWindow)
+				timerCh = timer.C
+			}
+			pending.Index = events.Index
+			pending.Events = append(pending.Events, events.Events...)
+			pendingCount++
+			pendingBytes += approxEventsSize(events)
+
+			if r.config.BatchMaxEvents > 0 && len(pending.Events) >= r.config.BatchMaxEvents {
+				flush()
+			} else if r.config.BatchMaxBytes > 0 && pendingBytes >= r.config.BatchMaxBytes {
+				flush()
+			}
+		case <-timerCh:
+			flush()
+		}
+	}
+}
+
+// sendBatch delivers events, retrying with backoff, and reconciles the
+// Inflight metric, which was incremented once per original queued batch
+// (queuedCount) regardless of how many were later coalesced together.
+func (r *SinkRunner) sendBatch(ctx context.Context, events *structs.Events, queuedCount int) {
+	if err := r.sendWithRetry(ctx, events); err != nil {
+		r.l.Error("giving up on event delivery after max retries", "error", err, "index", events.Index)
+	}
+	atomic.AddUint64(&r.metrics.Inflight, ^uint64(queuedCount-1))
+}
+
+// approxEventsSize returns the JSON-encoded size of events, marshaling each
+// queued batch on its own, used to enforce BatchMaxBytes. It returns 0 if
+// events cannot be marshaled.
+func approxEventsSize(events *structs.Events) int {
+	n, err := json.Marshal(events)
+	if err != nil {
+		return 0
+	}
+	return len(n)
+}
+
+// sendWithRetry attempts delivery, retrying with exponential backoff and
+// jitter until it succeeds, ctx is canceled, or MaxRetries is exhausted
+// (MaxRetries == 0 means retry forever). On success it checkpoints the new
+// lastIndex both in-memory and, if configured, durably.
+func (r *SinkRunner) sendWithRetry(ctx context.Context, events *structs.Events) error {
+	tracer := r.config.Observability.tracer()
+
+	var attempt int
+	for {
+		err := r.sendOnce(ctx, tracer, events, attempt)
+		if err == nil {
+			for topic, count := range eventCountsByTopic(events) {
+				r.metricVecs.eventsTotal.WithLabelValues(r.sink.Name(), topic).Add(float64(count))
+			}
+			r.checkpoint(events.Index)
+			r.metricVecs.lastAckedIndex.WithLabelValues(r.sink.Name()).Set(float64(events.Index))
+			return nil
+		}
+
+		if r.config.MaxRetries > 0 && attempt >= r.config.MaxRetries {
+			return err
+		}
+
+		atomic.AddUint64(&r.metrics.Retries, 1)
+		r.metricVecs.retriesTotal.WithLabelValues(r.sink.Name()).Inc()
+		wait := backoffDuration(r.config.InitialBackoff, r.config.MaxBackoff, attempt)
+		r.l.Warn("failed to send event, retrying", "error", err, "attempt", attempt, "wait", wait)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+
+		attempt++
+	}
+}
+
+// sendOnce wraps a single delivery attempt in an OTel span and records
+// send-duration metrics, so every retry is individually traceable.
+func (r *SinkRunner) sendOnce(ctx context.Context, tracer trace.Tracer, events *structs.Events, attempt int) error {
+	spanCtx, span := tracer.Start(ctx, "stream.sink.send", trace.WithAttributes(
+		attribute.String("sink.name", r.sink.Name()),
+		attribute.String("sink.type", r.sink.Type()),
+		attribute.Int("events.count", len(events.Events)),
+		attribute.Int64("events.index", int64(events.Index)),
+		attribute.Int("retry.attempt", attempt),
+	))
+	defer span.End()
+
+	start := time.Now()
+	err := r.sink.Send(spanCtx, events)
+	sendDuration := time.Since(start)
+
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		span.SetAttributes(attribute.Int("http.status_code", statusErr.Code))
+	}
+
+	r.metricVecs.sendDuration.WithLabelValues(r.sink.Name(), outcome).Observe(sendDuration.Seconds())
+
+	return err
+}
+
+// Metrics returns a snapshot of the runner's current counters.
+func (r *SinkRunner) Metrics() SinkMetrics {
+	return SinkMetrics{
+		Inflight:       atomic.LoadUint64(&r.metrics.Inflight),
+		Retries:        atomic.LoadUint64(&r.metrics.Retries),
+		Dropped:        atomic.LoadUint64(&r.metrics.Dropped),
+		LastAckedIndex: atomic.LoadUint64(&r.metrics.LastAckedIndex),
+	}
+}
+
+// Close unsubscribes from the broker and closes the underlying sink.
+func (r *SinkRunner) Close() error {
+	r.subscription.Unsubscribe()
+	return r.sink.Close()
+}
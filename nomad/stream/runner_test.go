@@ -0,0 +1,62 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad/nomad/stream/filter"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRunner(t *testing.T, prog *filter.Program) *SinkRunner {
+	t.Helper()
+	return &SinkRunner{
+		config: RunnerCfg{Filter: prog},
+		l:      hclog.NewNullLogger(),
+	}
+}
+
+func TestApplyFilter_NilFilterPassesEverything(t *testing.T) {
+	r := newTestRunner(t, nil)
+
+	in := &structs.Events{Index: 5, Events: []structs.Event{{Topic: "Allocation"}, {Topic: "Deployment"}}}
+	out := r.applyFilter(in)
+
+	require.Equal(t, in, out)
+}
+
+func TestApplyFilter_ExcludesOnlyTheEventThatErrors(t *testing.T) {
+	prog, err := filter.Compile(`Event.Payload.ClientStatus == "failed"`)
+	require.NoError(t, err)
+	r := newTestRunner(t, prog)
+
+	in := &structs.Events{
+		Index: 9,
+		Events: []structs.Event{
+			// No Payload map at all: Event.Payload.ClientStatus errors for
+			// this one event, but must not drop the other two.
+			{Topic: "Deployment"},
+			{Topic: "Allocation", Payload: map[string]interface{}{"ClientStatus": "failed"}},
+			{Topic: "Allocation", Payload: map[string]interface{}{"ClientStatus": "running"}},
+		},
+	}
+
+	out := r.applyFilter(in)
+
+	require.Equal(t, uint64(9), out.Index, "broker index must still be retained for checkpointing")
+	require.Len(t, out.Events, 1)
+	require.Equal(t, structs.Topic("Allocation"), out.Events[0].Topic)
+}
+
+func TestApplyFilter_AllExcludedRetainsIndex(t *testing.T) {
+	prog, err := filter.Compile(`Event.Topic == "Deployment"`)
+	require.NoError(t, err)
+	r := newTestRunner(t, prog)
+
+	in := &structs.Events{Index: 3, Events: []structs.Event{{Topic: "Allocation"}}}
+	out := r.applyFilter(in)
+
+	require.Equal(t, uint64(3), out.Index)
+	require.Empty(t, out.Events)
+}
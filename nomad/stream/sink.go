@@ -0,0 +1,29 @@
+package stream
+
+import (
+	"context"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// Sink is a delivery backend for a stream of events. Implementations are
+// driven by a SinkRunner, which owns subscribing to the broker, queuing,
+// retrying, and checkpointing; a Sink only needs to know how to deliver a
+// single batch of events.
+type Sink interface {
+	// Send delivers e, returning a non-nil error if delivery failed and
+	// should be retried.
+	Send(ctx context.Context, e *structs.Events) error
+
+	// Name uniquely identifies this sink instance, used for logging,
+	// metrics, and as the checkpoint store key.
+	Name() string
+
+	// Type identifies the kind of sink ("webhook", "kafka", "nats",
+	// "file"), used as a metric and span label.
+	Type() string
+
+	// Close releases any resources (connections, file handles) held by
+	// the sink.
+	Close() error
+}
@@ -0,0 +1,82 @@
+package stream
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/nomad/nomad/stream/filter"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// NewSink builds the Sink implementation selected by eventSink.Type
+// ("webhook", "kafka", "nats", or "file"), wrapped in a SinkRunner
+// subscribed to the topics declared on eventSink.Topics and filtering
+// events per eventSink.Filter. The returned runner is not started; the
+// caller runs it with SinkRunner.Start.
+func NewSink(eventSink *structs.EventSink, runnerCfg *RunnerCfg, broker *EventBroker) (*SinkRunner, error) {
+	subReq := subscribeRequest(eventSink)
+
+	if runnerCfg == nil {
+		runnerCfg = &RunnerCfg{}
+	}
+	if eventSink.Filter != "" {
+		prog, err := filter.Compile(eventSink.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("compiling sink filter: %w", err)
+		}
+		runnerCfg.Filter = prog
+	}
+
+	switch eventSink.Type {
+	case "", structs.SinkTypeWebhook:
+		cfg := &WebhookCfg{
+			Name:          eventSink.Name,
+			Address:       eventSink.Address,
+			BearerToken:   eventSink.BearerToken,
+			Format:        Format(eventSink.Format),
+			EventSource:   eventSink.EventSource,
+			GzipThreshold: eventSink.GzipThreshold,
+		}
+		if eventSink.HMACSecret != "" {
+			cfg.HMAC = &HMACConfig{
+				Algorithm: HMACAlgorithm(eventSink.HMACAlgorithm),
+				Secret:    eventSink.HMACSecret,
+			}
+		}
+		return NewWebhookSink(cfg, runnerCfg, broker, subReq)
+
+	case structs.SinkTypeKafka:
+		cfg := &KafkaCfg{
+			Name:    eventSink.Name,
+			Brokers: eventSink.KafkaBrokers,
+			Topic:   eventSink.KafkaTopic,
+			Acks:    eventSink.KafkaAcks,
+		}
+		return NewKafkaSink(cfg, runnerCfg, broker, subReq)
+
+	case structs.SinkTypeNATS:
+		cfg := &NATSCfg{
+			Name:          eventSink.Name,
+			URL:           eventSink.Address,
+			SubjectPrefix: eventSink.NATSSubjectPrefix,
+		}
+		return NewNATSSink(cfg, runnerCfg, broker, subReq)
+
+	case structs.SinkTypeFile:
+		cfg := &FileCfg{
+			Name:     eventSink.Name,
+			Path:     eventSink.FilePath,
+			MaxBytes: eventSink.FileMaxBytes,
+		}
+		return NewFileSink(cfg, runnerCfg, broker, subReq)
+
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", eventSink.Type)
+	}
+}
+
+// subscribeRequest builds the broker SubscribeRequest implied by
+// eventSink.Topics. An empty Topics map subscribes to everything, matching
+// the sink's previous (pre-filtering) behavior.
+func subscribeRequest(eventSink *structs.EventSink) *SubscribeRequest {
+	return &SubscribeRequest{Topics: eventSink.Topics}
+}
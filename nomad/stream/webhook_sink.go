@@ -4,18 +4,21 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
-	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/go-cleanhttp"
-	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/nomad/nomad/structs"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
+// defaultBatchWindow is how long NewWebhookSink coalesces event batches
+// before issuing an HTTP POST, unless the caller overrides RunnerCfg.BatchWindow.
+const defaultBatchWindow = 250 * time.Millisecond
+
 func defaultHttpClient() *http.Client {
 	httpClient := cleanhttp.DefaultClient()
 	transport := httpClient.Transport.(*http.Transport)
@@ -27,136 +30,258 @@ func defaultHttpClient() *http.Client {
 	return httpClient
 }
 
-type SinkCfg struct {
+// WebhookCfg configures a WebhookSink.
+type WebhookCfg struct {
+	// Name uniquely identifies this sink and is used as the checkpoint
+	// store key.
+	Name string
+
 	Address string
 
 	// HttpClient is the client to use. Default will be used if not provided.
 	HttpClient *http.Client
+
+	// HMAC, if set, signs every outgoing request with an HMAC over the
+	// request body and a replay-resistant timestamp. Ignored if Signer is
+	// set.
+	HMAC *HMACConfig
+
+	// BearerToken, if set, attaches a static "Authorization: Bearer"
+	// header to every outgoing request. Ignored if Signer or HMAC is set.
+	BearerToken string
+
+	// Signer, if set, is called to sign every outgoing request. It takes
+	// precedence over HMAC and BearerToken, allowing operators to inject
+	// mTLS client certs or custom auth schemes.
+	Signer RequestSigner
+
+	// Format selects the wire encoding. Defaults to FormatNomad.
+	Format Format
+
+	// EventSource identifies this cluster/region in the CloudEvents
+	// "source" field. Only used when Format is FormatCloudEventsBatch.
+	EventSource string
+
+	// GzipThreshold gzips the request body, setting
+	// "Content-Encoding: gzip", whenever the uncompressed body is at
+	// least this many bytes. Zero disables compression.
+	GzipThreshold int
+
+	// Propagator injects the active trace context into outgoing request
+	// headers. Defaults to the configured RunnerCfg.Observability's
+	// propagator (or the otel global, if that's unset too).
+	Propagator propagation.TextMapPropagator
 }
 
-func defaultCfg() *SinkCfg {
-	cfg := &SinkCfg{
+func defaultWebhookCfg() *WebhookCfg {
+	return &WebhookCfg{
 		HttpClient: defaultHttpClient(),
 	}
-	return cfg
 }
 
-type WebhookSink struct {
-	client *http.Client
-	config SinkCfg
-
-	subscription *Subscription
-
-	// lastIndex should be accessed atomically
-	lastIndex uint64
+// applyWebhookDefaults fills any zero-valued fields on cfg with the package
+// defaults, without clobbering explicit operator configuration.
+func applyWebhookDefaults(cfg *WebhookCfg) {
+	if cfg.HttpClient == nil {
+		cfg.HttpClient = defaultHttpClient()
+	}
+	if cfg.Format == "" {
+		cfg.Format = FormatNomad
+	}
+}
 
-	l hclog.Logger
+// resolveSigner picks the RequestSigner implied by cfg. An explicit Signer
+// takes precedence, followed by HMAC, then BearerToken. Returns nil if none
+// are configured.
+func resolveSigner(cfg *WebhookCfg) (RequestSigner, error) {
+	switch {
+	case cfg.Signer != nil:
+		return cfg.Signer, nil
+	case cfg.HMAC != nil:
+		return NewHMACSigner(*cfg.HMAC)
+	case cfg.BearerToken != "":
+		return &BearerTokenSigner{Token: cfg.BearerToken}, nil
+	default:
+		return nil, nil
+	}
 }
 
-func NewWebhookSink(cfg *SinkCfg, broker *EventBroker, subReq *SubscribeRequest) (*WebhookSink, error) {
-	defConfig := defaultCfg()
+// WebhookSink is a Sink that delivers events as an HTTP POST to a
+// configured address.
+type WebhookSink struct {
+	client *http.Client
+	config WebhookCfg
+	signer RequestSigner
+}
 
+// NewWebhookSink constructs a WebhookSink and wraps it in a SinkRunner
+// subscribed to broker per subReq.
+func NewWebhookSink(cfg *WebhookCfg, runnerCfg *RunnerCfg, broker *EventBroker, subReq *SubscribeRequest) (*SinkRunner, error) {
 	if cfg.Address == "" {
 		return nil, fmt.Errorf("invalid address for websink")
 	} else if _, err := url.Parse(cfg.Address); err != nil {
 		return nil, fmt.Errorf("invalid address '%s' : %v", cfg.Address, err)
 	}
 
-	httpClient := defConfig.HttpClient
+	applyWebhookDefaults(cfg)
 
-	sub, err := broker.Subscribe(subReq)
+	signer, err := resolveSigner(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("configuring webhook sink subscription: %w", err)
+		return nil, fmt.Errorf("configuring webhook sink auth: %w", err)
 	}
 
-	return &WebhookSink{
-		client:       httpClient,
-		config:       *cfg,
-		subscription: sub,
-	}, nil
+	if runnerCfg == nil {
+		runnerCfg = &RunnerCfg{}
+	}
+	if runnerCfg.BatchWindow == 0 {
+		runnerCfg.BatchWindow = defaultBatchWindow
+	}
+	if cfg.Propagator == nil {
+		cfg.Propagator = runnerCfg.Observability.propagator()
+	}
+
+	ws := &WebhookSink{
+		client: cfg.HttpClient,
+		config: *cfg,
+		signer: signer,
+	}
+
+	return NewSinkRunner(ws, runnerCfg, broker, subReq)
 }
 
+// NewWebhookSinks constructs a standalone WebhookSink from a structs.EventSink,
+// without wrapping it in a SinkRunner.
 func NewWebhookSinks(eventSink *structs.EventSink) (*WebhookSink, error) {
-	defConfig := defaultCfg()
-
 	if eventSink.Address == "" {
 		return nil, fmt.Errorf("invalid address for websink")
 	} else if _, err := url.Parse(eventSink.Address); err != nil {
 		return nil, fmt.Errorf("invalid address '%s' : %v", eventSink.Address, err)
 	}
 
-	httpClient := defConfig.HttpClient
+	cfg := &WebhookCfg{
+		Name:        eventSink.Name,
+		Address:     eventSink.Address,
+		BearerToken: eventSink.BearerToken,
+	}
+	if eventSink.HMACSecret != "" {
+		cfg.HMAC = &HMACConfig{
+			Algorithm: HMACAlgorithm(eventSink.HMACAlgorithm),
+			Secret:    eventSink.HMACSecret,
+		}
+	}
+	applyWebhookDefaults(cfg)
+
+	signer, err := resolveSigner(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("configuring webhook sink auth: %w", err)
+	}
 
 	return &WebhookSink{
-		client: httpClient,
+		client: cfg.HttpClient,
+		config: *cfg,
+		signer: signer,
 	}, nil
 }
 
-func (ws *WebhookSink) Start(ctx context.Context) {
-	defer ws.subscription.Unsubscribe()
-
-	// TODO handle reconnect
-	for {
-		events, err := ws.subscription.Next(ctx)
-		if err != nil {
-			if err == ErrSubscriptionClosed {
+func (ws *WebhookSink) Name() string {
+	return ws.config.Name
+}
 
-			}
-			return
-			// TODO handle err
-		}
-		if len(events.Events) == 0 {
-			continue
-		}
+func (ws *WebhookSink) Type() string {
+	return "webhook"
+}
 
-		if err := ws.Send(ctx, &events); err != nil {
-			ws.l.Error("failed to sending event to webhook", "error", err)
-			continue
-		}
-		// Update last successfully sent index
-		atomic.StoreUint64(&ws.lastIndex, events.Index)
-	}
+func (ws *WebhookSink) Close() error {
+	ws.client.CloseIdleConnections()
+	return nil
 }
 
 func (ws *WebhookSink) Send(ctx context.Context, e *structs.Events) error {
-	req, err := ws.toRequest(e)
+	req, body, err := ws.toRequest(e)
 	if err != nil {
 		return fmt.Errorf("converting event to request: %w", err)
 	}
-	req.WithContext(ctx)
+	req = req.WithContext(ctx)
 
-	err = ws.doRequest(req)
-	if err != nil {
+	if ws.signer != nil {
+		if err := ws.signer.Sign(req, body); err != nil {
+			return fmt.Errorf("signing webhook request: %w", err)
+		}
+	}
+
+	// Propagate the active span across the wire so the receiver can
+	// correlate its own processing with this delivery, using whatever
+	// propagator the pipeline was configured with.
+	propagator := ws.config.Propagator
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+	propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	if err := ws.doRequest(req); err != nil {
 		return fmt.Errorf("sending request to webhook %w", err)
 	}
 
 	return nil
 }
 
+// StatusError is returned by doRequest when the webhook responds with a
+// non-2xx status, so callers can recover the status code (e.g. to annotate
+// a trace span) without parsing the error string.
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhook returned non-2xx status: %d", e.Code)
+}
+
+// doRequest executes req and treats any network error, or any response
+// status outside the 2xx range, as a failure.
 func (ws *WebhookSink) doRequest(req *http.Request) error {
-	_, err := ws.client.Do(req)
+	resp, err := ws.client.Do(req)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
-	return nil
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &StatusError{Code: resp.StatusCode}
+	}
 
+	return nil
 }
 
-func (ws *WebhookSink) toRequest(e *structs.Events) (*http.Request, error) {
-	buf := bytes.NewBuffer(nil)
-	enc := json.NewEncoder(buf)
-	if err := enc.Encode(e); err != nil {
-		return nil, err
+// toRequest encodes e as the request body per ws.config.Format, gzipping it
+// if it's at least GzipThreshold bytes, and returns both the built request
+// and the raw (pre-compression) body bytes, since signers sign the logical
+// payload rather than its wire encoding.
+func (ws *WebhookSink) toRequest(e *structs.Events) (*http.Request, []byte, error) {
+	body, err := encodeBody(e, ws.config.Format, ws.config.EventSource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wireBody := body
+	gzipped := false
+	if ws.config.GzipThreshold > 0 && len(body) >= ws.config.GzipThreshold {
+		wireBody, err = gzipCompress(body)
+		if err != nil {
+			return nil, nil, err
+		}
+		gzipped = true
 	}
 
 	ctx := context.Background()
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ws.config.Address, buf)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ws.config.Address, bytes.NewReader(wireBody))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Content-Type", ws.config.Format.contentType())
+	if gzipped {
+		req.Header.Add("Content-Encoding", "gzip")
+	}
 
-	return req, nil
+	return req, body, nil
 }
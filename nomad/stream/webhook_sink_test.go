@@ -0,0 +1,152 @@
+package stream
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// newTestWebhookSink builds a WebhookSink pointed at srv without going
+// through NewWebhookSink/NewSinkRunner, which require a live broker.
+func newTestWebhookSink(t *testing.T, srv *httptest.Server, cfg WebhookCfg) *WebhookSink {
+	t.Helper()
+
+	cfg.Address = srv.URL
+	applyWebhookDefaults(&cfg)
+
+	signer, err := resolveSigner(&cfg)
+	require.NoError(t, err)
+
+	return &WebhookSink{
+		client: cfg.HttpClient,
+		config: cfg,
+		signer: signer,
+	}
+}
+
+func TestWebhookSink_Send_HMACSigned(t *testing.T) {
+	var gotSig, gotTS string
+	var gotBody []byte
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSig = r.Header.Get(DefaultSignatureHeader)
+		gotTS = r.Header.Get(DefaultTimestampHeader)
+		gotBody, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ws := newTestWebhookSink(t, srv, WebhookCfg{
+		HMAC: &HMACConfig{Secret: "s3cr3t"},
+	})
+
+	err := ws.Send(context.Background(), testEvents())
+	require.NoError(t, err)
+
+	require.NotEmpty(t, gotSig)
+	require.NotEmpty(t, gotTS)
+	require.True(t, VerifyHMAC(HMACSHA256, "s3cr3t", gotTS, gotSig, gotBody, 0))
+}
+
+func TestWebhookSink_Send_Gzipped(t *testing.T) {
+	var gotEncoding string
+	var gotBody []byte
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotEncoding = r.Header.Get("Content-Encoding")
+		gotBody, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ws := newTestWebhookSink(t, srv, WebhookCfg{GzipThreshold: 1})
+
+	err := ws.Send(context.Background(), testEvents())
+	require.NoError(t, err)
+	require.Equal(t, "gzip", gotEncoding)
+
+	r, err := gzip.NewReader(bytes.NewReader(gotBody))
+	require.NoError(t, err)
+	defer r.Close()
+
+	decompressed, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+
+	var decoded structs.Events
+	require.NoError(t, json.Unmarshal(decompressed, &decoded))
+	require.Equal(t, uint64(7), decoded.Index)
+}
+
+func TestWebhookSink_Send_CloudEventsFormat(t *testing.T) {
+	var gotContentType string
+	var gotBody []byte
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotContentType = r.Header.Get("Content-Type")
+		gotBody, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ws := newTestWebhookSink(t, srv, WebhookCfg{
+		Format:      FormatCloudEventsBatch,
+		EventSource: "nomad://region1",
+	})
+
+	err := ws.Send(context.Background(), testEvents())
+	require.NoError(t, err)
+	require.Equal(t, "application/cloudevents-batch+json", gotContentType)
+
+	var batch []map[string]interface{}
+	require.NoError(t, json.Unmarshal(gotBody, &batch))
+	require.Len(t, batch, 2)
+	require.Equal(t, "nomad://region1", batch[0]["source"])
+}
+
+func TestWebhookSink_Send_InjectsTraceparent(t *testing.T) {
+	var gotTraceparent string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ws := newTestWebhookSink(t, srv, WebhookCfg{
+		Propagator: propagation.TraceContext{},
+	})
+
+	tp := trace.NewTracerProvider()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "test-span")
+	defer span.End()
+
+	err := ws.Send(ctx, testEvents())
+	require.NoError(t, err)
+	require.NotEmpty(t, gotTraceparent)
+}
+
+func TestWebhookSink_Send_NonTwoxxReturnsStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	ws := newTestWebhookSink(t, srv, WebhookCfg{})
+
+	err := ws.Send(context.Background(), testEvents())
+	require.Error(t, err)
+
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, http.StatusInternalServerError, statusErr.Code)
+}
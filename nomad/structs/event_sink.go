@@ -0,0 +1,84 @@
+package structs
+
+// SinkType identifies the delivery backend an EventSink uses.
+type SinkType string
+
+const (
+	SinkTypeWebhook SinkType = "webhook"
+	SinkTypeKafka   SinkType = "kafka"
+	SinkTypeNATS    SinkType = "nats"
+	SinkTypeFile    SinkType = "file"
+)
+
+// EventSink is the user-facing, declarative configuration for a single
+// event stream sink, as parsed from agent config or the sink HTTP API.
+type EventSink struct {
+	// Name uniquely identifies this sink.
+	Name string
+
+	// Type selects the delivery backend. Defaults to SinkTypeWebhook.
+	Type SinkType
+
+	// Address is the sink's destination: an HTTP(S) URL for a webhook
+	// sink, or a server URL for a nats sink.
+	Address string
+
+	// Topics maps each topic this sink subscribes to onto the keys (e.g.
+	// job IDs) it cares about within that topic; an empty slice value
+	// means all keys. A nil/empty Topics subscribes to everything.
+	Topics map[Topic][]string
+
+	// Filter, if set, is a sandboxed boolean expression evaluated against
+	// each event; only events it matches are delivered. See package
+	// nomad/stream/filter for the expression syntax.
+	Filter string
+
+	// KafkaBrokers is the list of "host:port" bootstrap brokers. Only
+	// used when Type is SinkTypeKafka.
+	KafkaBrokers []string
+
+	// KafkaTopic is the Kafka topic events are produced to. Only used
+	// when Type is SinkTypeKafka.
+	KafkaTopic string
+
+	// KafkaAcks controls producer acknowledgement semantics ("0", "1",
+	// or "all"). Only used when Type is SinkTypeKafka.
+	KafkaAcks string
+
+	// NATSSubjectPrefix is prepended to the event's topic to form the
+	// publish subject. Only used when Type is SinkTypeNATS.
+	NATSSubjectPrefix string
+
+	// FilePath is the JSONL file events are appended to. Only used when
+	// Type is SinkTypeFile.
+	FilePath string
+
+	// FileMaxBytes rotates FilePath before a write that would take it past
+	// this size. Only used when Type is SinkTypeFile.
+	FileMaxBytes int64
+
+	// BearerToken, if set, is sent as a static "Authorization: Bearer"
+	// header on every delivery.
+	BearerToken string
+
+	// Format selects the wire encoding used by a webhook sink: "nomad"
+	// (default), "cloudevents-batch", or "ndjson".
+	Format string
+
+	// EventSource identifies this cluster/region in the CloudEvents
+	// "source" field. Only used when Format is "cloudevents-batch".
+	EventSource string
+
+	// GzipThreshold gzips a webhook sink's request body, setting
+	// "Content-Encoding: gzip", whenever the uncompressed body is at
+	// least this many bytes. Zero disables compression.
+	GzipThreshold int
+
+	// HMACSecret, if set, enables HMAC request signing using HMACAlgorithm
+	// (default "sha256").
+	HMACSecret string
+
+	// HMACAlgorithm selects the HMAC hash function ("sha256" or
+	// "sha512"). Defaults to "sha256".
+	HMACAlgorithm string
+}